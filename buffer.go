@@ -0,0 +1,19 @@
+package main
+
+import (
+	"crypto/rand"
+	"sync"
+)
+
+// newBufferPool returns a sync.Pool of blockSize-sized buffers, each filled
+// once from crypto/rand so writes reuse a ready payload instead of building
+// one from scratch every iteration.
+func newBufferPool(blockSize int64) *sync.Pool {
+	return &sync.Pool{
+		New: func() interface{} {
+			buf := make([]byte, blockSize)
+			_, _ = rand.Read(buf)
+			return buf
+		},
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import "unsafe"
+
+// alignedBuffer returns a []byte of exactly size bytes whose starting address
+// is aligned to align bytes, carved out of a slightly larger backing
+// allocation. Required for O_DIRECT reads/writes, which reject unaligned
+// buffers.
+func alignedBuffer(size, align int) []byte {
+	buf := make([]byte, size+align)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) % uintptr(align)); rem != 0 {
+		offset = align - rem
+	}
+	return buf[offset : offset+size]
+}
+
+// alignUp rounds n up to the next multiple of align. O_DIRECT requires every
+// write to be block-aligned, so the requested file size must be rounded up
+// before it's used for the space check or the write loop; otherwise the
+// last, partial block would get silently padded out to a full block.
+func alignUp(n, align int64) int64 {
+	if align <= 0 {
+		return n
+	}
+	if rem := n % align; rem != 0 {
+		return n + (align - rem)
+	}
+	return n
+}
+
+// ioMode describes which combination of --direct/--sync was used, surfaced
+// in the JSON report so buffered vs. direct numbers can be compared.
+func ioMode(direct, sync bool) string {
+	switch {
+	case direct && sync:
+		return "direct+sync"
+	case direct:
+		return "direct"
+	case sync:
+		return "sync"
+	default:
+		return "buffered"
+	}
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// alignSize is the alignment O_DIRECT buffers and offsets must respect.
+const alignSize = 4096
+
+// directSupported reports whether O_DIRECT is usable on this OS.
+func directSupported() bool {
+	return true
+}
+
+// directOpenFlag returns the OS-specific flag to request unbuffered I/O.
+func directOpenFlag() int {
+	return syscall.O_DIRECT
+}
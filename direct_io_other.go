@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+// alignSize is the alignment O_DIRECT buffers and offsets must respect.
+const alignSize = 4096
+
+// directSupported reports whether O_DIRECT is usable on this OS. Darwin and
+// Windows have no equivalent of Linux's O_DIRECT open flag, so --direct
+// falls back to buffered I/O there.
+func directSupported() bool {
+	return false
+}
+
+// directOpenFlag returns the OS-specific flag to request unbuffered I/O.
+func directOpenFlag() int {
+	return 0
+}
@@ -1,15 +1,13 @@
 package main
 
 import (
-	"bytes"
+	"crypto/rand"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
-	"math/rand"
 	"os"
 	"path/filepath"
-	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -36,58 +34,116 @@ func (f TempFile) String() string {
 // FileSizeLimit file max length
 //const FileSizeLimit int64 = 32 << 30
 
-// WriteFile write rand number to file
-func (f TempFile) WriteFile(fileSizeLimit int64, i int) (length int64, err error) {
-	rand.Seed(time.Now().UnixNano())
-	var (
-		file *os.File
-		buf  = new(bytes.Buffer)
-		n    int
-	)
-	file, err = os.OpenFile(f.String(), os.O_CREATE|os.O_RDWR, os.ModePerm)
+// WriteOptions controls how WriteFile performs its I/O.
+type WriteOptions struct {
+	Pool       *sync.Pool
+	Hist       *Histogram
+	Fsync      bool
+	FsyncEvery int
+	Direct     bool
+	Sync       bool
+}
+
+// WriteFile writes blockSize-sized buffers to the file until fileSizeLimit is
+// reached, optionally fsync'ing every FsyncEvery blocks and/or once at the
+// end. Each block's write latency is recorded into opts.Hist. When
+// opts.Direct is set (and supported by the OS) the file is opened with
+// O_DIRECT and writes use a page-aligned buffer instead of one drawn from
+// opts.Pool, bypassing the page cache.
+func (f TempFile) WriteFile(fileSizeLimit int64, opts WriteOptions) (length int64, err error) {
+	openFlags := os.O_CREATE | os.O_RDWR
+	direct := opts.Direct && directSupported()
+	if direct {
+		openFlags |= directOpenFlag()
+	}
+	if opts.Sync {
+		openFlags |= syscall.O_SYNC
+	}
+
+	var file *os.File
+	file, err = os.OpenFile(f.String(), openFlags, os.ModePerm)
 	if nil != err {
 		return
 	}
-
 	defer file.Close()
 
-	for {
-		if length >= fileSizeLimit {
-			break
-		}
+	var buf []byte
+	if direct {
+		pooled := opts.Pool.Get().([]byte)
+		buf = alignedBuffer(len(pooled), alignSize)
+		_, _ = rand.Read(buf)
+		opts.Pool.Put(pooled)
+	} else {
+		buf = opts.Pool.Get().([]byte)
+		defer opts.Pool.Put(buf)
+	}
 
-		str := strconv.Itoa(rand.Int())
+	var blocks int
+	for length < fileSizeLimit {
+		writeBuf := buf
+		if !direct {
+			if remaining := fileSizeLimit - length; remaining < int64(len(buf)) {
+				writeBuf = buf[:remaining]
+			}
+		}
 
-		// 使用buf，比直接写入，速度提升了N倍
-		buf.WriteString(str)
-		n, err = file.WriteString(buf.String())
+		var n int
+		blockStart := time.Now()
+		n, err = file.Write(writeBuf)
+		opts.Hist.Record(time.Since(blockStart))
 		if nil != err {
 			return
 		}
 
-		//buf.Reset() 会变的极其慢，和使用bufio.NewWrite()速度一样慢，原因有待研究
 		length += int64(n)
+		blocks++
+
+		if opts.FsyncEvery > 0 && blocks%opts.FsyncEvery == 0 {
+			if err = file.Sync(); nil != err {
+				return
+			}
+		}
 
 		fmt.Printf("\rWriteProcess: %.0f%c", float64(length)/float64(fileSizeLimit)*100, '%')
 	}
+
+	if opts.Fsync {
+		err = file.Sync()
+	}
+
 	return
 }
 
 var filePath = flag.String("path", "", "--path=file path ")
-var fileUnit = flag.Int64("size", 1, "--size=file size, the unit is GB, eg: --size=1, it is 1GB")
+var fileSizeFlag = flag.String("size", "1G", "--size=file size, accepts K/M/G/T suffixes, eg: --size=500M, --size=2G, --size=1T")
 var concurrent = flag.Int("con", 1, "--con=file number, the number of files generated concurrently")
 var jsonFile = flag.Bool("json", false, "--json, output json file")
 var deleteFile = flag.Bool("clean", false, "--clean, auto delete bench file")
+var blockSizeFlag = flag.String("block-size", "1M", "--block-size=write buffer size, accepts K/M/G/T suffixes, eg: --block-size=4K")
+var fsyncFlag = flag.Bool("fsync", false, "--fsync, call file.Sync() after writing each file")
+var directFlag = flag.Bool("direct", false, "--direct, open files with O_DIRECT to bypass the page cache (Linux only, falls back to buffered I/O elsewhere)")
+var syncFlag = flag.Bool("sync", false, "--sync, open files with O_SYNC")
+var fsyncEveryFlag = flag.Int("fsync-every", 0, "--fsync-every=N, call file.Sync() after every N blocks written, 0 disables")
 var help = flag.Bool("h", false, "show help")
 
 var helpUsage = func() {
 	fmt.Println(`
 help info:
-	--path  specify file store path, not filename
-	--size  specify file size. the unit is GB, eg: --size=1, it is 1GB
-	--con   specify the number of files generated concurrently
-	--json  output json file
-	--clean auto delete bench file
+	--path            specify file store path, not filename
+	--size            specify file size, accepts K/M/G/T suffixes, eg: --size=500M, --size=2G, --size=1T
+	--con             specify the number of files generated concurrently
+	--json            output json file
+	--clean           auto delete bench file
+	--block-size      write buffer size, accepts K/M/G/T suffixes, eg: --block-size=4K
+	--fsync           call file.Sync() after writing each file
+	--direct          open files with O_DIRECT to bypass the page cache (Linux only)
+	--sync            open files with O_SYNC
+	--fsync-every     call file.Sync() after every N blocks written, 0 disables
+	--segments        number of goroutines writing concurrently into each file via WriteAt
+	--read            run a read benchmark phase against the generated files
+	--read-mode       sequential|random, default sequential
+	--read-iterations number of random ReadAt calls per file in random mode
+	--read-only       skip the write phase and run --read against files already on --path
 	--h    show this info
 	`)
 }
@@ -95,8 +151,14 @@ help info:
 // FileFlag file flag param
 type FileFlag struct {
 	FilePath   string
-	FileUnit   int64
+	FileSize   int64
 	Concurrent int
+	BlockSize  int64
+	Fsync      bool
+	FsyncEvery int
+	Direct     bool
+	Sync       bool
+	Segments   int
 }
 
 // FileCountInfo file
@@ -106,6 +168,14 @@ type FileCountInfo struct {
 	UsedTime  time.Duration
 	ByteCount int64
 	WriteRate float64
+
+	ReadMode      string
+	ReadUsedTime  time.Duration
+	ReadByteCount int64
+	ReadRate      float64
+
+	WriteLatency LatencyStats
+	Segments     []*SegmentWriteResult
 }
 
 // Report report
@@ -113,6 +183,12 @@ type Report struct {
 	FileInfo         []*FileCountInfo
 	AverageWriteRate string
 	AverageUsedTime  string
+
+	AverageReadRate string
+	AverageReadTime string
+
+	WriteLatency LatencyStats
+	IOMode       string
 }
 
 func main() {
@@ -129,70 +205,130 @@ func main() {
 		os.Exit(0)
 	}
 
+	fileSize, err := parseSize(*fileSizeFlag)
+	if nil != err {
+		log.Panicln(err)
+	}
+
+	blockSize, err := parseSize(*blockSizeFlag)
+	if nil != err {
+		log.Panicln(err)
+	}
+	if blockSize <= 0 {
+		log.Printf("--block-size must be > 0, got %q", *blockSizeFlag)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if *directFlag && directSupported() {
+		if blockSize%alignSize != 0 {
+			log.Printf("--direct requires --block-size to be a multiple of %s, got %s",
+				formatBytes(alignSize), formatBytes(blockSize))
+			flag.Usage()
+			os.Exit(1)
+		}
+
+		if aligned := alignUp(fileSize, blockSize); aligned != fileSize {
+			log.Printf("--direct requires a block-size-aligned --size; rounding up from %s to %s",
+				formatBytes(fileSize), formatBytes(aligned))
+			fileSize = aligned
+		}
+	}
+
 	fileFlag := &FileFlag{
 		FilePath:   *filePath,
-		FileUnit:   *fileUnit,
+		FileSize:   fileSize,
 		Concurrent: *concurrent,
+		BlockSize:  blockSize,
+		Fsync:      *fsyncFlag,
+		FsyncEvery: *fsyncEveryFlag,
+		Direct:     *directFlag,
+		Sync:       *syncFlag,
+		Segments:   *segmentsFlag,
 	}
 	fileFlag.FilePath = filepath.Join(fileFlag.FilePath, "bench_file")
 	// 创建生成文件的目录
-	err := os.MkdirAll(fileFlag.FilePath, os.ModePerm)
+	err = os.MkdirAll(fileFlag.FilePath, os.ModePerm)
 	if nil != err {
 		log.Panicln(err)
 	}
 
-	// 检测当前空间是否满足存储
-	enough, err := CheckSpaceEnough(fileFlag)
-	if nil != err {
-		log.Printf("CheckSpaceEnough: %s", err)
-	}
-	if !enough {
-		os.Exit(0)
-	}
-	// 并发写入
-	log.Println("Start writing ......")
-	fmt.Println()
-	concurrentWrite := &ConcurrentWrite{}
-	for i := 0; i < fileFlag.Concurrent; i++ {
-		concurrentWrite.Wg.Add(1)
-		go concurrentWrite.Write(i, fileFlag)
-	}
-
-	concurrentWrite.Wg.Wait()
-	fmt.Println()
-	fmt.Println()
-	time.Sleep(100 * time.Millisecond)
+	concurrentWrite := &ConcurrentWrite{Pool: newBufferPool(blockSize), Hist: newHistogram()}
 
 	var (
-		totalWriteRate float64
-		totalUsedTime  float64
+		averageWriteRate string
+		averageUsedTime  string
+		writeLatency     LatencyStats
 	)
-	for _, info := range concurrentWrite.FileInfo {
-		fmt.Printf("%s: \n", info.FileName)
-		fmt.Printf("\tFileName:  %s\n", info.FileName)
-		fmt.Printf("\tFileSize:  %dGB(%d)\n", info.FileSize>>30, info.FileSize)
-		fmt.Printf("\tByteCount: %dGB(%d)\n", info.ByteCount>>30, info.ByteCount)
-		fmt.Printf("\tUsedTime:  %s\n", info.UsedTime.String())
-		fmt.Printf("\tWriteRate: %.2fM/s\n", info.WriteRate)
-
-		totalWriteRate += info.WriteRate
-		totalUsedTime += info.UsedTime.Seconds()
-	}
+	if *readOnlyFlag {
+		log.Println("--read-only set, skipping the write phase")
+	} else {
+		// 检测当前空间是否满足存储
+		enough, err := CheckSpaceEnough(fileFlag)
+		if nil != err {
+			log.Printf("CheckSpaceEnough: %s", err)
+		}
+		if !enough {
+			os.Exit(0)
+		}
+		// 并发写入
+		log.Printf("Start writing (io-mode: %s) ......\n", ioMode(fileFlag.Direct, fileFlag.Sync))
+		fmt.Println()
+		for i := 0; i < fileFlag.Concurrent; i++ {
+			concurrentWrite.Wg.Add(1)
+			go concurrentWrite.Write(i, fileFlag)
+		}
 
-	fmt.Printf("Count: \n")
-	averageWriteRate := fmt.Sprintf("%.2fM/s", totalWriteRate/float64(fileFlag.Concurrent))
-	fmt.Printf("\tAverageWriteRate: %s\n", averageWriteRate)
-	averageUsedTime := fmt.Sprintf("%.2fs", totalUsedTime/float64(fileFlag.Concurrent))
-	fmt.Printf("\tAverageUsedTime: %s", averageUsedTime)
+		concurrentWrite.Wg.Wait()
+		fmt.Println()
+		fmt.Println()
+		time.Sleep(100 * time.Millisecond)
+
+		var (
+			totalWriteRate float64
+			totalUsedTime  float64
+		)
+		for _, info := range concurrentWrite.FileInfo {
+			fmt.Printf("%s: \n", info.FileName)
+			fmt.Printf("\tFileName:  %s\n", info.FileName)
+			fmt.Printf("\tFileSize:  %s\n", formatBytes(info.FileSize))
+			fmt.Printf("\tByteCount: %s\n", formatBytes(info.ByteCount))
+			fmt.Printf("\tUsedTime:  %s\n", info.UsedTime.String())
+			fmt.Printf("\tWriteRate: %s\n", formatRate(info.WriteRate))
+			printLatencyStats(info.WriteLatency)
+			printSegmentResults(info.Segments)
+
+			totalWriteRate += info.WriteRate
+			totalUsedTime += info.UsedTime.Seconds()
+		}
 
-	fmt.Println()
-	log.Println("All file finished")
+		fmt.Printf("Count: \n")
+		averageWriteRate = formatRate(totalWriteRate / float64(fileFlag.Concurrent))
+		fmt.Printf("\tAverageWriteRate: %s\n", averageWriteRate)
+		averageUsedTime = fmt.Sprintf("%.2fs", totalUsedTime/float64(fileFlag.Concurrent))
+		fmt.Printf("\tAverageUsedTime: %s\n", averageUsedTime)
+		writeLatency = concurrentWrite.Hist.Stats()
+		printLatencyStats(writeLatency)
+
+		fmt.Println()
+		log.Println("All file finished")
+	}
+
+	var averageReadRate, averageReadTime string
+	if *readBench || *readOnlyFlag {
+		concurrentWrite.FileInfo, averageReadRate, averageReadTime = runReadBench(fileFlag, concurrentWrite.FileInfo)
+		log.Println("All file read")
+	}
 
 	if *jsonFile {
 		report := Report{
 			FileInfo:         concurrentWrite.FileInfo,
 			AverageWriteRate: averageWriteRate,
 			AverageUsedTime:  averageUsedTime,
+			AverageReadRate:  averageReadRate,
+			AverageReadTime:  averageReadTime,
+			WriteLatency:     writeLatency,
+			IOMode:           ioMode(fileFlag.Direct, fileFlag.Sync),
 		}
 
 		f, err := os.OpenFile("bench-report.json", os.O_RDWR|os.O_CREATE, os.ModePerm)
@@ -217,6 +353,8 @@ type ConcurrentWrite struct {
 	Wg       sync.WaitGroup
 	FileInfo []*FileCountInfo
 	Lc       sync.Mutex
+	Pool     *sync.Pool
+	Hist     *Histogram
 }
 
 // Write write
@@ -225,10 +363,34 @@ func (c *ConcurrentWrite) Write(i int, flagParam *FileFlag) {
 
 	filename := filepath.Join(flagParam.FilePath, fmt.Sprintf("random_file_%d", i))
 	file := TempFile(filename)
-	filesize := flagParam.FileUnit << 30
+	filesize := flagParam.FileSize
 	start := time.Now()
 
-	bytecount, err := file.WriteFile(filesize, i)
+	var (
+		bytecount  int64
+		hist       *Histogram
+		segResults []*SegmentWriteResult
+		err        error
+	)
+	if flagParam.Segments > 1 {
+		bytecount, segResults, hist, err = file.WriteFileSegmented(filesize, flagParam.Segments, WriteOptions{
+			Pool:       c.Pool,
+			Fsync:      flagParam.Fsync,
+			FsyncEvery: flagParam.FsyncEvery,
+			Direct:     flagParam.Direct,
+			Sync:       flagParam.Sync,
+		})
+	} else {
+		hist = newHistogram()
+		bytecount, err = file.WriteFile(filesize, WriteOptions{
+			Pool:       c.Pool,
+			Hist:       hist,
+			Fsync:      flagParam.Fsync,
+			FsyncEvery: flagParam.FsyncEvery,
+			Direct:     flagParam.Direct,
+			Sync:       flagParam.Sync,
+		})
+	}
 	if nil != err {
 		log.Printf("write file(%s): %s\n", filename, err)
 		return
@@ -236,17 +398,20 @@ func (c *ConcurrentWrite) Write(i int, flagParam *FileFlag) {
 
 	end := time.Now()
 	useTime := end.Sub(start)
-	writeRate := float64(bytecount>>20) / useTime.Seconds()
+	writeRate := float64(bytecount) / useTime.Seconds()
 	info := &FileCountInfo{
-		FileName:  filename,
-		FileSize:  filesize,
-		UsedTime:  useTime,
-		ByteCount: bytecount,
-		WriteRate: writeRate,
+		FileName:     filename,
+		FileSize:     filesize,
+		UsedTime:     useTime,
+		ByteCount:    bytecount,
+		WriteRate:    writeRate,
+		WriteLatency: hist.Stats(),
+		Segments:     segResults,
 	}
 
 	c.Lc.Lock()
 	c.FileInfo = append(c.FileInfo, info)
+	c.Hist.Merge(hist)
 	c.Lc.Unlock()
 }
 
@@ -265,9 +430,9 @@ func PrintPathStatInfo(stat *syscall.Statfs_t, needResource uint64) (total, avai
 	fmt.Printf("Path Space: \n")
 	total = stat.Blocks * uint64(stat.Bsize)
 	avail = stat.Bavail * uint64(stat.Bsize)
-	fmt.Printf("\tTotal: %dGB(%d)\n", total>>30, total)
-	fmt.Printf("\tAvail: %dGB(%d)\n", avail>>30, avail)
-	fmt.Printf("\tNeed:  %dGB(%d)\n", needResource>>30, needResource)
+	fmt.Printf("\tTotal: %s\n", formatBytes(int64(total)))
+	fmt.Printf("\tAvail: %s\n", formatBytes(int64(avail)))
+	fmt.Printf("\tNeed:  %s\n", formatBytes(int64(needResource)))
 	fmt.Println()
 
 	return
@@ -280,13 +445,13 @@ func CheckSpaceEnough(fileFlag *FileFlag) (enough bool, err error) {
 		return
 	}
 
-	needResource := uint64(fileFlag.FileUnit << 30 * int64(fileFlag.Concurrent))
+	needResource := uint64(fileFlag.FileSize * int64(fileFlag.Concurrent))
 
 	_, avail := PrintPathStatInfo(stat, needResource)
 
 	if needResource > avail {
-		log.Printf("not enough space, avail: %dGB(%d), need: %dGB(%d), diff: %dGB(%d)",
-			avail>>30, avail, needResource>>30, needResource, (needResource-avail)>>30, needResource-avail)
+		log.Printf("not enough space, avail: %s, need: %s, diff: %s",
+			formatBytes(int64(avail)), formatBytes(int64(needResource)), formatBytes(int64(needResource-avail)))
 		return
 	}
 
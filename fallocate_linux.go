@@ -0,0 +1,15 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// fallocate pre-allocates size bytes for file using syscall.Fallocate so the
+// segmented writers in WriteFileSegmented don't race on filesystem block
+// allocation.
+func fallocate(file *os.File, size int64) error {
+	return syscall.Fallocate(int(file.Fd()), 0, 0, size)
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// fallocate is a no-op outside Linux; Truncate already reserves the size,
+// it's just not physically backed ahead of time.
+func fallocate(file *os.File, size int64) error {
+	return nil
+}
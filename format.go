@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// formatBytes renders a byte count using IEC units, eg "1.50 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for nn := n / unit; nn >= unit; nn /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.2f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// formatRate renders a bytes/sec rate using IEC units, eg "742.30 MiB/s".
+func formatRate(bytesPerSec float64) string {
+	return formatBytes(int64(bytesPerSec)) + "/s"
+}
+
+// parseSize parses a byte count with an optional K/M/G/T suffix (binary,
+// case-insensitive) into a raw byte count, eg: "4K" -> 4096, "1M" -> 1<<20.
+// A bare number with no suffix is treated as raw bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty size")
+	}
+
+	unit := int64(1)
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		unit = 1 << 10
+	case 'm', 'M':
+		unit = 1 << 20
+	case 'g', 'G':
+		unit = 1 << 30
+	case 't', 'T':
+		unit = 1 << 40
+	}
+
+	numPart := s
+	if unit != 1 {
+		numPart = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(numPart, 64)
+	if nil != err {
+		return 0, fmt.Errorf("invalid size %q: %s", s, err)
+	}
+
+	return int64(n * float64(unit)), nil
+}
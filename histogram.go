@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// histogramBuckets is the number of log-linear buckets spanning
+// histogramMinDuration..histogramMaxDuration.
+const histogramBuckets = 128
+
+const histogramMinDuration = time.Microsecond
+const histogramMaxDuration = 10 * time.Second
+
+var (
+	histogramLogMin = math.Log(float64(histogramMinDuration))
+	histogramLogMax = math.Log(float64(histogramMaxDuration))
+)
+
+// Histogram is a compact log-linear latency histogram covering roughly
+// 1µs to 10s, used to track per-block write latency.
+type Histogram struct {
+	counts [histogramBuckets]uint64
+	count  uint64
+	sum    time.Duration
+	min    time.Duration
+	max    time.Duration
+}
+
+// newHistogram returns an empty Histogram.
+func newHistogram() *Histogram {
+	return &Histogram{min: time.Duration(math.MaxInt64)}
+}
+
+// Record adds a single latency sample.
+func (h *Histogram) Record(d time.Duration) {
+	if d < h.min {
+		h.min = d
+	}
+	if d > h.max {
+		h.max = d
+	}
+	h.sum += d
+	h.count++
+	h.counts[histogramBucket(d)]++
+}
+
+// Merge folds other's samples into h.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.count == 0 {
+		return
+	}
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.sum += other.sum
+	h.count += other.count
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+}
+
+// histogramBucket maps a duration to its bucket index on a log scale.
+func histogramBucket(d time.Duration) int {
+	if d <= histogramMinDuration {
+		return 0
+	}
+	if d >= histogramMaxDuration {
+		return histogramBuckets - 1
+	}
+
+	logD := math.Log(float64(d))
+	idx := int((logD - histogramLogMin) / (histogramLogMax - histogramLogMin) * float64(histogramBuckets-1))
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= histogramBuckets {
+		idx = histogramBuckets - 1
+	}
+	return idx
+}
+
+// histogramBucketCeiling returns the upper latency bound represented by bucket i.
+func histogramBucketCeiling(i int) time.Duration {
+	logD := histogramLogMin + (histogramLogMax-histogramLogMin)*float64(i+1)/float64(histogramBuckets-1)
+	return time.Duration(math.Exp(logD))
+}
+
+// Percentile returns the p-th percentile latency (0 < p <= 100).
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	target := uint64(math.Ceil(p / 100 * float64(h.count)))
+	if target == 0 {
+		target = 1
+	}
+
+	var cum uint64
+	for i, c := range h.counts {
+		cum += c
+		if cum >= target {
+			return histogramBucketCeiling(i)
+		}
+	}
+	return h.max
+}
+
+// LatencyStats is the summarized view of a Histogram used in reports.
+type LatencyStats struct {
+	Min  time.Duration
+	Max  time.Duration
+	Mean time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P95  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+}
+
+// Stats computes min/max/mean and the p50/p90/p95/p99/p99.9 percentiles.
+func (h *Histogram) Stats() LatencyStats {
+	if h.count == 0 {
+		return LatencyStats{}
+	}
+
+	return LatencyStats{
+		Min:  h.min,
+		Max:  h.max,
+		Mean: time.Duration(int64(h.sum) / int64(h.count)),
+		P50:  h.Percentile(50),
+		P90:  h.Percentile(90),
+		P95:  h.Percentile(95),
+		P99:  h.Percentile(99),
+		P999: h.Percentile(99.9),
+	}
+}
+
+// printLatencyStats prints a LatencyStats block in the console summary.
+func printLatencyStats(s LatencyStats) {
+	fmt.Printf("\tLatency:   min=%s mean=%s max=%s p50=%s p90=%s p95=%s p99=%s p99.9=%s\n",
+		s.Min, s.Mean, s.Max, s.P50, s.P90, s.P95, s.P99, s.P999)
+}
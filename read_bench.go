@@ -0,0 +1,188 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// readBlockSize is the buffer size used for both streaming sequential reads
+// and individual random ReadAt calls.
+const readBlockSize int64 = 4 << 20
+
+var readBench = flag.Bool("read", false, "--read, run a read benchmark phase against the generated files")
+var readMode = flag.String("read-mode", "sequential", "--read-mode=sequential|random")
+var readIterations = flag.Int("read-iterations", 1000, "--read-iterations=N, number of random ReadAt calls per file in random mode")
+var readOnlyFlag = flag.Bool("read-only", false, "--read-only, skip the write phase and run --read against files already on --path")
+
+// ReadFile reads the file according to mode ("sequential" or "random") and
+// returns the total number of bytes read.
+func (f TempFile) ReadFile(mode string, iterations int) (byteCount int64, err error) {
+	if mode == "random" {
+		return f.readRandom(iterations)
+	}
+	return f.readSequential()
+}
+
+// readSequential streams the whole file once, reading readBlockSize at a time.
+func (f TempFile) readSequential() (byteCount int64, err error) {
+	file, err := os.Open(f.String())
+	if nil != err {
+		return
+	}
+	defer file.Close()
+
+	buf := make([]byte, readBlockSize)
+	for {
+		var n int
+		n, err = file.Read(buf)
+		byteCount += int64(n)
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if nil != err {
+			return
+		}
+	}
+	return
+}
+
+// readRandom issues iterations ReadAt calls at uniformly random offsets.
+func (f TempFile) readRandom(iterations int) (byteCount int64, err error) {
+	fileSize, err := f.GetFileSize()
+	if nil != err {
+		return
+	}
+	if fileSize == 0 {
+		return
+	}
+
+	blockSize := readBlockSize
+	if blockSize > fileSize {
+		blockSize = fileSize
+	}
+
+	file, err := os.Open(f.String())
+	if nil != err {
+		return
+	}
+	defer file.Close()
+
+	buf := make([]byte, blockSize)
+	rand.Seed(time.Now().UnixNano())
+	for i := 0; i < iterations; i++ {
+		offset := rand.Int63n(fileSize - blockSize + 1)
+
+		var n int
+		n, err = file.ReadAt(buf, offset)
+		if nil != err && err != io.EOF {
+			return
+		}
+		err = nil
+		byteCount += int64(n)
+	}
+	return
+}
+
+// ConcurrentRead drives the read phase across the generated files,
+// mirroring ConcurrentWrite.
+type ConcurrentRead struct {
+	Wg       sync.WaitGroup
+	FileInfo []*FileCountInfo
+	Lc       sync.Mutex
+}
+
+// Read reads a single generated file and records its throughput.
+func (c *ConcurrentRead) Read(i int, flagParam *FileFlag) {
+	defer c.Wg.Done()
+
+	filename := filepath.Join(flagParam.FilePath, fmt.Sprintf("random_file_%d", i))
+	file := TempFile(filename)
+	start := time.Now()
+
+	bytecount, err := file.ReadFile(*readMode, *readIterations)
+	if nil != err {
+		log.Printf("read file(%s): %s\n", filename, err)
+		return
+	}
+
+	useTime := time.Now().Sub(start)
+	readRate := float64(bytecount) / useTime.Seconds()
+	info := &FileCountInfo{
+		FileName:      filename,
+		ReadMode:      *readMode,
+		ReadUsedTime:  useTime,
+		ReadByteCount: bytecount,
+		ReadRate:      readRate,
+	}
+
+	c.Lc.Lock()
+	c.FileInfo = append(c.FileInfo, info)
+	c.Lc.Unlock()
+}
+
+// mergeReadInfo folds read results into the matching write FileCountInfo
+// (matched by FileName), appending a standalone entry if none exists yet,
+// e.g. when --read is used against files from a previous --clean=false run.
+func mergeReadInfo(writeInfo []*FileCountInfo, readInfo []*FileCountInfo) []*FileCountInfo {
+	for _, rinfo := range readInfo {
+		merged := false
+		for _, winfo := range writeInfo {
+			if winfo.FileName == rinfo.FileName {
+				winfo.ReadMode = rinfo.ReadMode
+				winfo.ReadUsedTime = rinfo.ReadUsedTime
+				winfo.ReadByteCount = rinfo.ReadByteCount
+				winfo.ReadRate = rinfo.ReadRate
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			writeInfo = append(writeInfo, rinfo)
+		}
+	}
+	return writeInfo
+}
+
+// runReadBench runs the read phase over the files generated for fileFlag and
+// returns the merged file info plus the average rate/time summary strings.
+func runReadBench(fileFlag *FileFlag, writeInfo []*FileCountInfo) (info []*FileCountInfo, averageReadRate, averageReadTime string) {
+	log.Println("Start reading ......")
+	fmt.Println()
+
+	concurrentRead := &ConcurrentRead{}
+	for i := 0; i < fileFlag.Concurrent; i++ {
+		concurrentRead.Wg.Add(1)
+		go concurrentRead.Read(i, fileFlag)
+	}
+	concurrentRead.Wg.Wait()
+	fmt.Println()
+
+	var totalReadRate, totalReadTime float64
+	for _, rinfo := range concurrentRead.FileInfo {
+		fmt.Printf("%s: \n", rinfo.FileName)
+		fmt.Printf("\tReadMode:  %s\n", rinfo.ReadMode)
+		fmt.Printf("\tReadBytes: %s\n", formatBytes(rinfo.ReadByteCount))
+		fmt.Printf("\tReadTime:  %s\n", rinfo.ReadUsedTime.String())
+		fmt.Printf("\tReadRate:  %s\n", formatRate(rinfo.ReadRate))
+
+		totalReadRate += rinfo.ReadRate
+		totalReadTime += rinfo.ReadUsedTime.Seconds()
+	}
+
+	averageReadRate = formatRate(totalReadRate / float64(fileFlag.Concurrent))
+	averageReadTime = fmt.Sprintf("%.2fs", totalReadTime/float64(fileFlag.Concurrent))
+	fmt.Printf("Count: \n")
+	fmt.Printf("\tAverageReadRate: %s\n", averageReadRate)
+	fmt.Printf("\tAverageReadTime: %s", averageReadTime)
+	fmt.Println()
+
+	return mergeReadInfo(writeInfo, concurrentRead.FileInfo), averageReadRate, averageReadTime
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var segmentsFlag = flag.Int("segments", 1, "--segments=N, number of goroutines writing concurrently into each file via WriteAt")
+
+// SegmentWriteResult is the throughput/latency summary for one segment of a
+// segmented write.
+type SegmentWriteResult struct {
+	Segment   int
+	Offset    int64
+	Length    int64
+	UsedTime  time.Duration
+	WriteRate float64
+	Latency   LatencyStats
+}
+
+// WriteFileSegmented pre-allocates the file to fileSize and fills it with
+// segments concurrent WriteAt writers, each owning a disjoint byte range.
+// opts.Hist is ignored; each segment keeps its own histogram and they're
+// merged into the returned hist instead.
+func (f TempFile) WriteFileSegmented(fileSize int64, segments int, opts WriteOptions) (length int64, segResults []*SegmentWriteResult, hist *Histogram, err error) {
+	openFlags := os.O_CREATE | os.O_RDWR
+	direct := opts.Direct && directSupported()
+	if direct {
+		openFlags |= directOpenFlag()
+	}
+	if opts.Sync {
+		openFlags |= syscall.O_SYNC
+	}
+
+	file, err := os.OpenFile(f.String(), openFlags, os.ModePerm)
+	if nil != err {
+		return
+	}
+	defer file.Close()
+
+	if err = file.Truncate(fileSize); nil != err {
+		return
+	}
+	if ferr := fallocate(file, fileSize); nil != ferr {
+		log.Printf("fallocate(%s): %s\n", f.String(), ferr)
+	}
+
+	segSize := fileSize / int64(segments)
+	if direct {
+		// O_DIRECT offsets must be aligned, so every segment boundary is too.
+		segSize -= segSize % alignSize
+	}
+	hist = newHistogram()
+	segResults = make([]*SegmentWriteResult, segments)
+
+	var (
+		wg sync.WaitGroup
+		lc sync.Mutex
+	)
+	for s := 0; s < segments; s++ {
+		offset := int64(s) * segSize
+		segLen := segSize
+		if s == segments-1 {
+			segLen = fileSize - offset
+		}
+
+		wg.Add(1)
+		go func(segment int, offset, segLen int64) {
+			defer wg.Done()
+
+			segHist := newHistogram()
+			var buf []byte
+			if direct {
+				pooled := opts.Pool.Get().([]byte)
+				buf = alignedBuffer(len(pooled), alignSize)
+				_, _ = rand.Read(buf)
+				opts.Pool.Put(pooled)
+			} else {
+				buf = opts.Pool.Get().([]byte)
+				defer opts.Pool.Put(buf)
+			}
+
+			start := time.Now()
+			var written, blocks int64
+			for written < segLen {
+				writeBuf := buf
+				if remaining := segLen - written; remaining < int64(len(buf)) {
+					writeBuf = buf[:remaining]
+				}
+
+				blockStart := time.Now()
+				n, werr := file.WriteAt(writeBuf, offset+written)
+				segHist.Record(time.Since(blockStart))
+				if nil != werr {
+					lc.Lock()
+					err = werr
+					lc.Unlock()
+					return
+				}
+				written += int64(n)
+				blocks++
+
+				if opts.FsyncEvery > 0 && blocks%int64(opts.FsyncEvery) == 0 {
+					if werr := file.Sync(); nil != werr {
+						lc.Lock()
+						err = werr
+						lc.Unlock()
+						return
+					}
+				}
+			}
+			useTime := time.Since(start)
+
+			lc.Lock()
+			length += written
+			hist.Merge(segHist)
+			segResults[segment] = &SegmentWriteResult{
+				Segment:   segment,
+				Offset:    offset,
+				Length:    written,
+				UsedTime:  useTime,
+				WriteRate: float64(written) / useTime.Seconds(),
+				Latency:   segHist.Stats(),
+			}
+			lc.Unlock()
+		}(s, offset, segLen)
+	}
+	wg.Wait()
+
+	if nil == err && opts.Fsync {
+		err = file.Sync()
+	}
+
+	return
+}
+
+// printSegmentResults prints per-segment throughput for a segmented write.
+func printSegmentResults(segResults []*SegmentWriteResult) {
+	for _, seg := range segResults {
+		if nil == seg {
+			continue
+		}
+		fmt.Printf("\tSegment %d: offset=%d length=%s rate=%s\n",
+			seg.Segment, seg.Offset, formatBytes(seg.Length), formatRate(seg.WriteRate))
+	}
+}